@@ -0,0 +1,339 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+
+	"ryde/httperr"
+)
+
+const (
+	bearerPrefix = "Bearer "
+)
+
+type refreshTokenRecord struct {
+	ID        *primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID  `bson:"userId"`
+	TokenHash string              `bson:"tokenHash"`
+	ExpiresAt time.Time           `bson:"expiresAt"`
+	CreatedAt time.Time           `bson:"createdAt"`
+}
+
+type passwordResetRecord struct {
+	ID        *primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    primitive.ObjectID  `bson:"userId"`
+	TokenHash string              `bson:"tokenHash"`
+	ExpiresAt time.Time           `bson:"expiresAt"`
+	CreatedAt time.Time           `bson:"createdAt"`
+}
+
+type AuthController struct {
+	service AuthService
+}
+
+func (a *AuthController) Signup(c *gin.Context) {
+	payload := struct {
+		Email    *string `json:"email" binding:"required"`
+		Password *string `json:"password" binding:"required"`
+	}{}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_request", "invalid signup request")
+		return
+	}
+
+	user, err := a.service.signup(c.Request.Context(), *payload.Email, *payload.Password)
+	if httperr.WriteDBError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, user)
+}
+
+func (a *AuthController) Login(c *gin.Context) {
+	payload := struct {
+		Email    *string `json:"email" binding:"required"`
+		Password *string `json:"password" binding:"required"`
+	}{}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_request", "invalid login request")
+		return
+	}
+
+	accessToken, refreshToken, err := a.service.login(c.Request.Context(), *payload.Email, *payload.Password)
+	if err != nil {
+		httperr.Write(c, http.StatusUnauthorized, "invalid_credentials", "invalid email or password")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accessToken": accessToken, "refreshToken": refreshToken})
+}
+
+func (a *AuthController) Refresh(c *gin.Context) {
+	payload := struct {
+		RefreshToken *string `json:"refreshToken" binding:"required"`
+	}{}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_request", "invalid refresh request")
+		return
+	}
+
+	accessToken, refreshToken, err := a.service.refresh(c.Request.Context(), *payload.RefreshToken)
+	if err != nil {
+		httperr.Write(c, http.StatusUnauthorized, "invalid_refresh_token", "invalid refresh token")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accessToken": accessToken, "refreshToken": refreshToken})
+}
+
+func (a *AuthController) ForgotPassword(c *gin.Context) {
+	payload := struct {
+		Email *string `json:"email" binding:"required"`
+	}{}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_request", "invalid forgot-password request")
+		return
+	}
+
+	if err := a.service.forgotPassword(c.Request.Context(), *payload.Email); httperr.WriteDBError(c, err) {
+		return
+	}
+
+	// Always report success so callers can't use this endpoint to enumerate emails.
+	c.Status(http.StatusAccepted)
+}
+
+func (a *AuthController) ResetPassword(c *gin.Context) {
+	payload := struct {
+		Token    *string `json:"token" binding:"required"`
+		Password *string `json:"password" binding:"required"`
+	}{}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_request", "invalid reset-password request")
+		return
+	}
+
+	if err := a.service.resetPassword(c.Request.Context(), *payload.Token, *payload.Password); err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_reset_token", "invalid or expired reset token")
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// RequireAuth returns gin middleware that enforces a valid bearer access
+// token signed with secret, stashing the authenticated user's ObjectID hex
+// in the context under "userId".
+func RequireAuth(secret []byte) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			httperr.Write(c, http.StatusUnauthorized, "missing_token", "missing bearer token")
+			return
+		}
+
+		claims := jwt.RegisteredClaims{}
+		_, err := jwt.ParseWithClaims(strings.TrimPrefix(header, bearerPrefix), &claims, func(t *jwt.Token) (interface{}, error) {
+			return secret, nil
+		}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+		if err != nil {
+			httperr.Write(c, http.StatusUnauthorized, "invalid_token", "invalid access token")
+			return
+		}
+
+		c.Set("userId", claims.Subject)
+		c.Next()
+	}
+}
+
+type AuthService interface {
+	signup(ctx context.Context, email, password string) (*User, error)
+	login(ctx context.Context, email, password string) (accessToken string, refreshToken string, err error)
+	refresh(ctx context.Context, refreshToken string) (accessToken string, newRefreshToken string, err error)
+	forgotPassword(ctx context.Context, email string) error
+	resetPassword(ctx context.Context, token, password string) error
+}
+
+type authService struct {
+	users         *mongo.Collection
+	refreshTokens *mongo.Collection
+	resets        *mongo.Collection
+	secret        []byte
+	accessTTL     time.Duration
+	refreshTTL    time.Duration
+}
+
+func (s *authService) signup(ctx context.Context, email, password string) (*User, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	hashed := string(hash)
+	now := time.Now().UTC().Format(time.RFC3339)
+	version := int64(1)
+
+	user := &User{
+		Email:     &email,
+		Password:  &hashed,
+		CreatedAt: &now,
+		UpdatedAt: &now,
+		Version:   &version,
+	}
+
+	res, err := s.users.InsertOne(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+	if insertedId, ok := res.InsertedID.(primitive.ObjectID); ok {
+		user.ID = &insertedId
+	}
+
+	return user, nil
+}
+
+func (s *authService) login(ctx context.Context, email, password string) (string, string, error) {
+	var user User
+	if err := s.users.FindOne(ctx, bson.D{{Key: "email", Value: email}, {Key: "deletedAt", Value: nil}}).Decode(&user); err != nil {
+		return "", "", fmt.Errorf("invalid credentials")
+	}
+	if user.Password == nil || bcrypt.CompareHashAndPassword([]byte(*user.Password), []byte(password)) != nil {
+		return "", "", fmt.Errorf("invalid credentials")
+	}
+
+	return s.issueTokenPair(ctx, *user.ID)
+}
+
+func (s *authService) refresh(ctx context.Context, token string) (string, string, error) {
+	var stored refreshTokenRecord
+	if err := s.refreshTokens.FindOne(ctx, bson.D{{Key: "tokenHash", Value: hashToken(token)}}).Decode(&stored); err != nil {
+		return "", "", fmt.Errorf("invalid refresh token")
+	}
+	if time.Now().UTC().After(stored.ExpiresAt) {
+		return "", "", fmt.Errorf("refresh token expired")
+	}
+
+	if _, err := s.refreshTokens.DeleteOne(ctx, bson.D{{Key: "_id", Value: stored.ID}}); err != nil {
+		return "", "", fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return s.issueTokenPair(ctx, stored.UserID)
+}
+
+func (s *authService) forgotPassword(ctx context.Context, email string) error {
+	var user User
+	if err := s.users.FindOne(ctx, bson.D{{Key: "email", Value: email}, {Key: "deletedAt", Value: nil}}).Decode(&user); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// Don't reveal whether the email is registered.
+			return nil
+		}
+		return fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	_, err = s.resets.InsertOne(ctx, passwordResetRecord{
+		UserID:    *user.ID,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(time.Hour),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to persist reset token: %w", err)
+	}
+
+	// TODO: email `token` to the user once a mail provider is wired up.
+	return nil
+}
+
+func (s *authService) resetPassword(ctx context.Context, token, password string) error {
+	var reset passwordResetRecord
+	if err := s.resets.FindOne(ctx, bson.D{{Key: "tokenHash", Value: hashToken(token)}}).Decode(&reset); err != nil {
+		return fmt.Errorf("invalid reset token")
+	}
+	if time.Now().UTC().After(reset.ExpiresAt) {
+		return fmt.Errorf("reset token expired")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	_, err = s.users.UpdateByID(ctx, reset.UserID, bson.D{{Key: "$set", Value: bson.D{{Key: "password", Value: string(hash)}}}})
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if _, err := s.resets.DeleteOne(ctx, bson.D{{Key: "_id", Value: reset.ID}}); err != nil {
+		return fmt.Errorf("failed to invalidate reset token: %w", err)
+	}
+
+	return nil
+}
+
+func (s *authService) issueTokenPair(ctx context.Context, userId primitive.ObjectID) (string, string, error) {
+	accessToken, err := s.newAccessToken(userId)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to sign access token: %w", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	now := time.Now().UTC()
+	_, err = s.refreshTokens.InsertOne(ctx, refreshTokenRecord{
+		UserID:    userId,
+		TokenHash: hashToken(token),
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to persist refresh token: %w", err)
+	}
+
+	return accessToken, token, nil
+}
+
+func (s *authService) newAccessToken(userId primitive.ObjectID) (string, error) {
+	now := time.Now().UTC()
+	claims := jwt.RegisteredClaims{
+		Subject:   userId.Hex(),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
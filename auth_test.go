@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type MockAuthService struct {
+	mockSignup         func() (*User, error)
+	mockLogin          func() (string, string, error)
+	mockRefresh        func() (string, string, error)
+	mockForgotPassword func() error
+	mockResetPassword  func() error
+}
+
+func (m MockAuthService) signup(context.Context, string, string) (*User, error) {
+	return m.mockSignup()
+}
+
+func (m MockAuthService) login(context.Context, string, string) (string, string, error) {
+	return m.mockLogin()
+}
+
+func (m MockAuthService) refresh(context.Context, string) (string, string, error) {
+	return m.mockRefresh()
+}
+
+func (m MockAuthService) forgotPassword(context.Context, string) error {
+	return m.mockForgotPassword()
+}
+
+func (m MockAuthService) resetPassword(context.Context, string, string) error {
+	return m.mockResetPassword()
+}
+
+func NewMockAuthService() MockAuthService {
+	return MockAuthService{
+		mockSignup: func() (*User, error) {
+			return nil, nil
+		},
+		mockLogin: func() (string, string, error) {
+			return "", "", nil
+		},
+		mockRefresh: func() (string, string, error) {
+			return "", "", nil
+		},
+		mockForgotPassword: func() error {
+			return nil
+		},
+		mockResetPassword: func() error {
+			return nil
+		},
+	}
+}
+
+func TestAuthControllerSignup(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	email := "jane@example.com"
+	svc.mockSignup = func() (*User, error) {
+		return &User{Email: &email}, nil
+	}
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"email": email, "password": "hunter2"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/signup", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	var got User
+	json.Unmarshal(w.Body.Bytes(), &got)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, email, *got.Email)
+}
+
+func TestAuthControllerSignupErr(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	svc.mockSignup = func() (*User, error) {
+		return nil, fmt.Errorf("oops")
+	}
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"email": "jane@example.com", "password": "hunter2"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/signup", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestAuthControllerLogin(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	svc.mockLogin = func() (string, string, error) {
+		return "access-token", "refresh-token", nil
+	}
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"email": "jane@example.com", "password": "hunter2"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/login", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	var got map[string]string
+	json.Unmarshal(w.Body.Bytes(), &got)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "access-token", got["accessToken"])
+	assert.Equal(t, "refresh-token", got["refreshToken"])
+}
+
+func TestAuthControllerLoginErr(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	svc.mockLogin = func() (string, string, error) {
+		return "", "", fmt.Errorf("invalid credentials")
+	}
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"email": "jane@example.com", "password": "wrong"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/login", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthControllerRefresh(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	svc.mockRefresh = func() (string, string, error) {
+		return "new-access-token", "new-refresh-token", nil
+	}
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"refreshToken": "refresh-token"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/refresh", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	var got map[string]string
+	json.Unmarshal(w.Body.Bytes(), &got)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "new-access-token", got["accessToken"])
+	assert.Equal(t, "new-refresh-token", got["refreshToken"])
+}
+
+func TestAuthControllerRefreshErr(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	svc.mockRefresh = func() (string, string, error) {
+		return "", "", fmt.Errorf("invalid refresh token")
+	}
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"refreshToken": "bogus"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/refresh", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthControllerForgotPassword(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"email": "jane@example.com"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/forgot-password", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestAuthControllerResetPassword(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"token": "reset-token", "password": "newpassword"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/reset-password", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthControllerResetPasswordErr(t *testing.T) {
+	r := gin.Default()
+	svc := NewMockAuthService()
+	svc.mockResetPassword = func() error {
+		return fmt.Errorf("invalid reset token")
+	}
+	authRoutes(r.Group("/apis"), &AuthController{svc})
+
+	body, _ := json.Marshal(map[string]string{"token": "bogus", "password": "newpassword"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/apis/auth/reset-password", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRequireAuthMissingToken(t *testing.T) {
+	r := gin.Default()
+	r.GET("/protected", RequireAuth([]byte("secret")), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRequireAuthValidToken(t *testing.T) {
+	secret := []byte("secret")
+	r := gin.Default()
+	r.GET("/protected", RequireAuth(secret), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("userId"))
+	})
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "user-1"}).SignedString(secret)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", bearerPrefix+token)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "user-1", w.Body.String())
+}
+
+func TestRequireAuthRejectsNoneAlgorithm(t *testing.T) {
+	secret := []byte("secret")
+	r := gin.Default()
+	r.GET("/protected", RequireAuth(secret), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.RegisteredClaims{Subject: "user-1"}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", bearerPrefix+token)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
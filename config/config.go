@@ -0,0 +1,112 @@
+// Package config loads ryde's runtime configuration from environment
+// variables, failing fast when a required value is missing or malformed.
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultDatabaseName    = "ryde"
+	defaultListenAddr      = ":8080"
+	defaultLogLevel        = "info"
+	defaultReadTimeout     = 5 * time.Second
+	defaultWriteTimeout    = 10 * time.Second
+	defaultShutdownTimeout = 10 * time.Second
+)
+
+// Config holds ryde's runtime configuration, loaded once at startup by Load.
+type Config struct {
+	DatabaseURI     string
+	DatabaseName    string
+	ListenAddr      string
+	LogLevel        zerolog.Level
+	JWTSecret       string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Load reads Config from the environment, applying defaults for optional
+// values and failing fast if a required value is missing or malformed.
+func Load() (*Config, error) {
+	databaseURI := os.Getenv("DATABASE_URL")
+	if databaseURI == "" {
+		return nil, fmt.Errorf("DATABASE_URL must be set")
+	}
+
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return nil, fmt.Errorf("JWT_SECRET must be set")
+	}
+
+	logLevel, err := parseLogLevel(envOrDefault("LOG_LEVEL", defaultLogLevel))
+	if err != nil {
+		return nil, err
+	}
+
+	readTimeout, err := durationOrDefault("READ_TIMEOUT", defaultReadTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	writeTimeout, err := durationOrDefault("WRITE_TIMEOUT", defaultWriteTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTimeout, err := durationOrDefault("SHUTDOWN_TIMEOUT", defaultShutdownTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Config{
+		DatabaseURI:     databaseURI,
+		DatabaseName:    envOrDefault("DATABASE_NAME", defaultDatabaseName),
+		ListenAddr:      envOrDefault("LISTEN_ADDR", defaultListenAddr),
+		LogLevel:        logLevel,
+		JWTSecret:       jwtSecret,
+		ReadTimeout:     readTimeout,
+		WriteTimeout:    writeTimeout,
+		ShutdownTimeout: shutdownTimeout,
+	}, nil
+}
+
+func parseLogLevel(raw string) (zerolog.Level, error) {
+	switch raw {
+	case "debug":
+		return zerolog.DebugLevel, nil
+	case "info":
+		return zerolog.InfoLevel, nil
+	case "warn":
+		return zerolog.WarnLevel, nil
+	case "error":
+		return zerolog.ErrorLevel, nil
+	default:
+		return zerolog.NoLevel, fmt.Errorf("invalid LOG_LEVEL %q: must be one of debug, info, warn, error", raw)
+	}
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func durationOrDefault(key string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, raw, err)
+	}
+	return d, nil
+}
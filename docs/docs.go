@@ -0,0 +1,41 @@
+// Package docs serves the OpenAPI spec and an embedded Swagger UI for the
+// ryde API at GET /swagger/*filepath.
+package docs
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed swaggerui
+var swaggerUI embed.FS
+
+//go:embed openapi.yaml
+var openapiSpec embed.FS
+
+// Handler serves the embedded Swagger UI bundle and the openapi.yaml spec
+// it points at. Mount it with r.GET("/swagger/*filepath", docs.Handler()).
+func Handler() gin.HandlerFunc {
+	ui, err := fs.Sub(swaggerUI, "swaggerui")
+	if err != nil {
+		panic(err)
+	}
+	fileServer := http.FileServer(http.FS(ui))
+
+	return func(c *gin.Context) {
+		path := strings.TrimPrefix(c.Param("filepath"), "/")
+		if path == "openapi.yaml" {
+			c.FileFromFS("openapi.yaml", http.FS(openapiSpec))
+			return
+		}
+
+		// Leave root requests at "/" rather than rewriting to "/index.html" so
+		// http.FileServer serves it directly instead of redirecting.
+		c.Request.URL.Path = "/" + path
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	}
+}
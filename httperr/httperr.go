@@ -0,0 +1,85 @@
+// Package httperr maps errors from Mongo-backed services onto HTTP
+// responses with a consistent {"error", "code", "requestId"} envelope.
+package httperr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrInvalidID is returned by services when a caller-supplied ID isn't a
+	// valid ObjectID hex string.
+	ErrInvalidID = errors.New("invalid id")
+	// ErrNotFound is returned by services when a document doesn't exist.
+	ErrNotFound = errors.New("not found")
+	// ErrVersionConflict is returned by services when a caller's optimistic
+	// concurrency version doesn't match the document's current version.
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// logger logs internal errors WriteDBError doesn't surface to callers. It
+// defaults to a plain stderr logger so the package is usable before
+// SetLogger is called, and is replaced with the application's configured
+// logger during startup.
+var logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+
+// SetLogger replaces the logger used to report internal errors, so they're
+// emitted through the same structured, leveled logger as the rest of the
+// application.
+func SetLogger(l zerolog.Logger) {
+	logger = l
+}
+
+// WriteDBError inspects err and, if non-nil, writes the appropriate error
+// envelope for a Mongo-backed handler and aborts the context. It returns
+// true when a response was written, so callers can write:
+//
+//	if httperr.WriteDBError(c, err) {
+//		return
+//	}
+func WriteDBError(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments), errors.Is(err, ErrNotFound):
+		Write(c, http.StatusNotFound, "not_found", "not found")
+	case errors.Is(err, ErrInvalidID), errors.Is(err, primitive.ErrInvalidHex):
+		Write(c, http.StatusBadRequest, "invalid_id", "invalid id")
+	case errors.Is(err, ErrVersionConflict):
+		Write(c, http.StatusConflict, "version_conflict", "version conflict")
+	case mongo.IsDuplicateKeyError(err):
+		Write(c, http.StatusConflict, "duplicate_key", "already exists")
+	case errors.Is(err, context.DeadlineExceeded):
+		Write(c, http.StatusGatewayTimeout, "deadline_exceeded", "request timed out")
+	case errors.Is(err, context.Canceled):
+		Write(c, 499, "canceled", "request canceled")
+	default:
+		requestId, _ := c.Get("requestId")
+		logger.Error().Interface("requestId", requestId).Err(err).Msg("internal error")
+		Write(c, http.StatusInternalServerError, "internal", "internal server error")
+	}
+
+	return true
+}
+
+// Write aborts the context with the given status and a
+// {"error", "code", "requestId"} envelope, tagging it with the request ID
+// set by RequestID middleware, if any.
+func Write(c *gin.Context, status int, code, message string) {
+	requestId, _ := c.Get("requestId")
+	c.AbortWithStatusJSON(status, gin.H{
+		"error":     message,
+		"code":      code,
+		"requestId": requestId,
+	})
+}
@@ -0,0 +1,89 @@
+package httperr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWriteDBError(t *testing.T) {
+	duplicateKeyErr := mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}
+
+	tests := map[string]struct {
+		err        error
+		wantWrote  bool
+		wantStatus int
+	}{
+		"nil error": {
+			err:       nil,
+			wantWrote: false,
+		},
+		"not found": {
+			err:        ErrNotFound,
+			wantWrote:  true,
+			wantStatus: http.StatusNotFound,
+		},
+		"mongo no documents": {
+			err:        mongo.ErrNoDocuments,
+			wantWrote:  true,
+			wantStatus: http.StatusNotFound,
+		},
+		"invalid id": {
+			err:        ErrInvalidID,
+			wantWrote:  true,
+			wantStatus: http.StatusBadRequest,
+		},
+		"invalid hex": {
+			err:        primitive.ErrInvalidHex,
+			wantWrote:  true,
+			wantStatus: http.StatusBadRequest,
+		},
+		"version conflict": {
+			err:        ErrVersionConflict,
+			wantWrote:  true,
+			wantStatus: http.StatusConflict,
+		},
+		"duplicate key": {
+			err:        duplicateKeyErr,
+			wantWrote:  true,
+			wantStatus: http.StatusConflict,
+		},
+		"deadline exceeded": {
+			err:        context.DeadlineExceeded,
+			wantWrote:  true,
+			wantStatus: http.StatusGatewayTimeout,
+		},
+		"canceled": {
+			err:        context.Canceled,
+			wantWrote:  true,
+			wantStatus: 499,
+		},
+		"unknown error": {
+			err:        errors.New("oops"),
+			wantWrote:  true,
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+
+			wrote := WriteDBError(c, tc.err)
+
+			assert.Equal(t, tc.wantWrote, wrote)
+			if tc.wantWrote {
+				assert.Equal(t, tc.wantStatus, w.Code)
+			}
+		})
+	}
+}
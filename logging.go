@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Logger returns gin middleware that emits one structured JSON log line per
+// request via logger, in place of gin's default text logger. Each line is
+// tagged with the request ID set by RequestID and, when the request passed
+// through RequireAuth, the authenticated user ID.
+func Logger(logger zerolog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		event := logger.Info()
+		if len(c.Errors) > 0 || c.Writer.Status() >= http.StatusInternalServerError {
+			event = logger.Error()
+		}
+
+		requestId, _ := c.Get("requestId")
+		event = event.
+			Interface("requestId", requestId).
+			Str("method", c.Request.Method).
+			Str("path", c.Request.URL.Path).
+			Int("status", c.Writer.Status()).
+			Dur("latency", time.Since(start))
+
+		if userId, ok := c.Get("userId"); ok {
+			event = event.Interface("userId", userId)
+		}
+
+		event.Msg("request")
+	}
+}
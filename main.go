@@ -3,13 +3,39 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ryde/config"
+	"ryde/docs"
+	"ryde/httperr"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	indexCreationTimeout   = 10 * time.Second
+
+	databaseName    = "ryde"
+	usersCollection = "users"
+)
+
+// logger is replaced with the application's configured logger in Main, once
+// config has been loaded. It defaults to a plain stderr logger so it's
+// usable by code, like the background index-creation goroutine in InitDb,
+// that can run before Main finishes setting it up.
+var logger = zerolog.New(os.Stderr).With().Timestamp().Logger()
+
 func main() {
 	if err := Main(); err != nil {
 		fmt.Println(err)
@@ -18,19 +44,56 @@ func main() {
 }
 
 func Main() error {
-	db, err := InitDb(os.Getenv("DATABASE_URL"))
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	logger = zerolog.New(os.Stdout).Level(cfg.LogLevel).With().Timestamp().Logger()
+	httperr.SetLogger(logger)
+
+	db, err := InitDb(cfg.DatabaseURI)
 	if err != nil {
 		return fmt.Errorf("failed to initialize db: %w", err)
 	}
-	defer func() {
-		if err := db.Disconnect(context.Background()); err != nil {
-			panic(err)
+
+	router := InitControllers(db, cfg, logger)
+
+	srv := &http.Server{
+		Addr:         cfg.ListenAddr,
+		Handler:      router,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
 		}
 	}()
 
-	router := InitControllers(db)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("server error: %w", err)
+	case <-stop:
+		logger.Info().Msg("shutdown signal received")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+	if err := db.Disconnect(ctx); err != nil {
+		return fmt.Errorf("failed to disconnect db: %w", err)
+	}
 
-	return router.Run(":8080")
+	return nil
 }
 
 func InitDb(uri string) (*mongo.Client, error) {
@@ -45,25 +108,85 @@ func InitDb(uri string) (*mongo.Client, error) {
 		return nil, err
 	}
 
+	// Index creation talks to the server, so it's done best-effort in the
+	// background rather than blocking startup on a database that may not be
+	// reachable yet.
+	go ensureUserIndexes(client.Database(databaseName).Collection(usersCollection))
+
 	return client, nil
 }
 
-func InitControllers(conn *mongo.Client) *gin.Engine {
-	r := gin.Default()
+func ensureUserIndexes(coll *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), indexCreationTimeout)
+	defer cancel()
+
+	_, err := coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "deletedAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("failed to ensure user indexes")
+	}
+}
+
+func InitControllers(conn *mongo.Client, cfg *config.Config, logger zerolog.Logger) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), RequestID(), Logger(logger))
 	apis := r.Group("/apis")
-	db := conn.Database("ryde")
+	db := conn.Database(cfg.DatabaseName)
+
+	jwtSecret := []byte(cfg.JWTSecret)
+
+	// API docs
+	r.GET("/swagger/*filepath", docs.Handler())
+
+	// Auth APIs
+	authService := &authService{
+		users:         db.Collection(usersCollection),
+		refreshTokens: db.Collection("refresh_tokens"),
+		resets:        db.Collection("password_resets"),
+		secret:        jwtSecret,
+		accessTTL:     defaultAccessTokenTTL,
+		refreshTTL:    defaultRefreshTokenTTL,
+	}
+	authController := &AuthController{authService}
+	authRoutes(apis, authController)
 
 	// User APIs
-	userService := &userService{db.Collection("users")}
+	userService := &userService{db.Collection(usersCollection)}
 	userController := &UserController{userService}
-	userRoutes(apis, userController)
+	userRoutes(apis, userController, RequireAuth(jwtSecret))
 
 	return r
 }
 
-func userRoutes(apis *gin.RouterGroup, userController *UserController) {
-	apis.GET("/users/:id", userController.GetUser)
+func authRoutes(apis *gin.RouterGroup, authController *AuthController) {
+	apis.POST("/auth/signup", authController.Signup)
+	apis.POST("/auth/login", authController.Login)
+	apis.POST("/auth/refresh", authController.Refresh)
+	apis.POST("/auth/forgot-password", authController.ForgotPassword)
+	apis.POST("/auth/reset-password", authController.ResetPassword)
+}
+
+func userRoutes(apis *gin.RouterGroup, userController *UserController, requireAuth gin.HandlerFunc) {
+	apis.GET("/users", requireAuth, userController.ListUsers)
+	apis.GET("/users/:id", requireAuth, userController.GetUser)
 	apis.POST("/users", userController.CreateUser)
-	apis.PUT("/users/:id", userController.UpdateUser)
-	apis.DELETE("/users/:id", userController.DeleteUser)
+	apis.PUT("/users/:id", requireAuth, userController.UpdateUser)
+	apis.DELETE("/users/:id", requireAuth, userController.DeleteUser)
+}
+
+// RequestID stashes a UUID under "requestId" in the gin context for every
+// request, so handlers and the httperr package can tag responses and logs
+// with it.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := uuid.NewString()
+		c.Set("requestId", requestId)
+		c.Writer.Header().Set("X-Request-Id", requestId)
+		c.Next()
+	}
 }
@@ -5,46 +5,132 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"ryde/httperr"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
 )
 
+// userFilterFields whitelists the User bson field names callers may filter
+// or sort on, so query params can never be forwarded as raw Mongo operators.
+var userFilterFields = filterableFields(reflect.TypeOf(User{}))
+
+func filterableFields(t reflect.Type) map[string]struct{} {
+	fields := make(map[string]struct{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("bson"), ",")[0]
+		if name == "" || name == "-" || name == "_id" || name == "password" {
+			continue
+		}
+		fields[name] = struct{}{}
+	}
+	return fields
+}
+
 type User struct {
 	ID          *primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
 	Name        *string             `json:"name,omitempty" bson:"name,omitempty"`
 	Dob         *string             `json:"dob,omitempty" bson:"dob,omitempty"`
 	Address     *string             `json:"address,omitempty" bson:"address,omitempty"`
 	Description *string             `json:"description,omitempty" bson:"description,omitempty"`
+	Email       *string             `json:"email,omitempty" bson:"email,omitempty"`
+	Password    *string             `json:"-" bson:"password,omitempty"`
 	CreatedAt   *string             `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt   *string             `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+	DeletedAt   *string             `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	Version     *int64              `json:"version,omitempty" bson:"version,omitempty"`
 }
 
 type UserController struct {
 	service UserService
 }
 
+// GetUser handles GET /apis/users/{id}. See docs/openapi.yaml.
 func (u *UserController) GetUser(c *gin.Context) {
-	user, err := u.service.get(c.Request.Context(), c.Param("id"))
-	if err != nil && errors.Is(err, mongo.ErrNoDocuments) {
-		c.AbortWithStatus(http.StatusNoContent)
+	includeDeleted := c.Query("includeDeleted") == "true"
+
+	user, err := u.service.get(c.Request.Context(), c.Param("id"), includeDeleted)
+	if httperr.WriteDBError(c, err) {
 		return
 	}
 	c.JSON(http.StatusOK, user)
 }
 
+// ListUsers handles GET /apis/users. See docs/openapi.yaml.
+func (u *UserController) ListUsers(c *gin.Context) {
+	page, err := queryPositiveInt(c.Query("page"), 1)
+	if err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_page", fmt.Sprintf("invalid page: %v", err))
+		return
+	}
+
+	pageSize, err := queryPositiveInt(c.Query("pageSize"), defaultPageSize)
+	if err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_page_size", fmt.Sprintf("invalid pageSize: %v", err))
+		return
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	sort, err := parseUserSort(c.Query("sort"))
+	if err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_sort", err.Error())
+		return
+	}
+
+	filter, err := parseUserFilter(c.Request.URL.Query())
+	if err != nil {
+		httperr.Write(c, http.StatusBadRequest, "invalid_filter", err.Error())
+		return
+	}
+	if c.Query("includeDeleted") != "true" {
+		filter = append(bson.D{{Key: "deletedAt", Value: nil}}, filter...)
+	}
+
+	users, total, err := u.service.list(c.Request.Context(), filter, sort, page, pageSize)
+	if httperr.WriteDBError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":     users,
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+	})
+}
+
+// CreateUserRequest is the POST /apis/users request body.
+type CreateUserRequest struct {
+	Name        *string `json:"name" binding:"required"`
+	Dob         *string `json:"dob" binding:"required"`
+	Address     *string `json:"address" binding:"required"`
+	Description *string `json:"description"`
+}
+
+// CreateUser handles POST /apis/users. See docs/openapi.yaml.
 func (u *UserController) CreateUser(c *gin.Context) {
-	payload := struct {
-		Name        *string `json:"name" binding:"required"`
-		Dob         *string `json:"dob" binding:"required"`
-		Address     *string `json:"address" binding:"required"`
-		Description *string `json:"description"`
-	}{}
+	payload := CreateUserRequest{}
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid new user request"))
+		httperr.Write(c, http.StatusBadRequest, "invalid_request", "invalid new user request")
 		return
 	}
 
@@ -55,24 +141,28 @@ func (u *UserController) CreateUser(c *gin.Context) {
 		Description: payload.Description,
 	}
 
-	err := u.service.create(c.Request.Context(), user)
-	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+	if err := u.service.create(c.Request.Context(), user); httperr.WriteDBError(c, err) {
 		return
 	}
 
 	c.JSON(http.StatusCreated, user)
 }
 
+// UpdateUserRequest is the PUT /apis/users/{id} request body. Version must
+// match the document's current version, enforcing optimistic concurrency.
+type UpdateUserRequest struct {
+	Name        *string `json:"name" bson:"name,omitempty"`
+	Dob         *string `json:"dob" bson:"dob,omitempty"`
+	Address     *string `json:"address" bson:"address,omitempty"`
+	Description *string `json:"description" bson:"description,omitempty"`
+	Version     *int64  `json:"version" binding:"required"`
+}
+
+// UpdateUser handles PUT /apis/users/{id}. See docs/openapi.yaml.
 func (u *UserController) UpdateUser(c *gin.Context) {
-	payload := struct {
-		Name        *string `json:"name" bson:"name,omitempty"`
-		Dob         *string `json:"dob" bson:"dob,omitempty"`
-		Address     *string `json:"address" bson:"address,omitempty"`
-		Description *string `json:"description" bson:"description,omitempty"`
-	}{}
+	payload := UpdateUserRequest{}
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("invalid update user request"))
+		httperr.Write(c, http.StatusBadRequest, "invalid_request", "invalid update user request")
 		return
 	}
 
@@ -82,51 +172,133 @@ func (u *UserController) UpdateUser(c *gin.Context) {
 		Address:     payload.Address,
 		Description: payload.Description,
 	}
-	updatedUser, err := u.service.update(c.Request.Context(), c.Param("id"), user)
-	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
-		return
-	}
-	if updatedUser == nil {
-		c.AbortWithError(http.StatusBadRequest, fmt.Errorf("user with id `%v` not found", c.Param("id")))
+	updatedUser, err := u.service.update(c.Request.Context(), c.Param("id"), user, *payload.Version)
+	if httperr.WriteDBError(c, err) {
 		return
 	}
 
 	c.JSON(http.StatusAccepted, updatedUser)
 }
 
+// DeleteUser handles DELETE /apis/users/{id}. See docs/openapi.yaml. By
+// default this soft-deletes the user; pass ?hard=true to remove the
+// document permanently.
 func (u *UserController) DeleteUser(c *gin.Context) {
-	err := u.service.delete(c.Request.Context(), c.Param("id"))
-	if err != nil {
-		c.AbortWithError(http.StatusInternalServerError, err)
+	hard := c.Query("hard") == "true"
+
+	err := u.service.delete(c.Request.Context(), c.Param("id"), hard)
+	if httperr.WriteDBError(c, err) {
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
 
+func queryPositiveInt(raw string, fallback int) (int, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("must be a positive integer, got %q", raw)
+	}
+	return n, nil
+}
+
+// filterOperators maps the `__`-suffixed operator syntax (e.g.
+// `createdAt__gte`) accepted on GET /apis/users to Mongo query operators.
+var filterOperators = map[string]string{
+	"eq":       "$eq",
+	"ne":       "$ne",
+	"gt":       "$gt",
+	"gte":      "$gte",
+	"lt":       "$lt",
+	"lte":      "$lte",
+	"contains": "$regex",
+}
+
+func parseUserFilter(query url.Values) (bson.D, error) {
+	filter := bson.D{}
+	for key, values := range query {
+		if key == "page" || key == "pageSize" || key == "sort" || key == "includeDeleted" {
+			continue
+		}
+
+		field, op := key, "eq"
+		if idx := strings.LastIndex(key, "__"); idx != -1 {
+			field, op = key[:idx], key[idx+2:]
+		}
+		if _, ok := userFilterFields[field]; !ok {
+			return nil, fmt.Errorf("unknown filter field %q", field)
+		}
+		mongoOp, ok := filterOperators[op]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter operator %q", op)
+		}
+
+		value := values[0]
+		switch mongoOp {
+		case "$eq":
+			filter = append(filter, bson.E{Key: field, Value: value})
+		case "$regex":
+			filter = append(filter, bson.E{Key: field, Value: bson.D{{Key: "$regex", Value: regexp.QuoteMeta(value)}, {Key: "$options", Value: "i"}}})
+		default:
+			filter = append(filter, bson.E{Key: field, Value: bson.D{{Key: mongoOp, Value: value}}})
+		}
+	}
+
+	return filter, nil
+}
+
+func parseUserSort(raw string) (bson.D, error) {
+	if raw == "" {
+		return bson.D{{Key: "_id", Value: 1}}, nil
+	}
+
+	sort := bson.D{}
+	for _, field := range strings.Split(raw, ",") {
+		dir := 1
+		if strings.HasPrefix(field, "-") {
+			dir = -1
+			field = field[1:]
+		}
+		if _, ok := userFilterFields[field]; !ok {
+			return nil, fmt.Errorf("unknown sort field %q", field)
+		}
+		sort = append(sort, bson.E{Key: field, Value: dir})
+	}
+
+	return sort, nil
+}
+
 type UserService interface {
-	get(ctx context.Context, id string) (*User, error)
+	get(ctx context.Context, id string, includeDeleted bool) (*User, error)
+	list(ctx context.Context, filter, sort bson.D, page, pageSize int) ([]*User, int64, error)
 	create(ctx context.Context, user *User) error
-	update(ctx context.Context, id string, user *User) (*User, error)
-	delete(ctx context.Context, id string) error
+	update(ctx context.Context, id string, user *User, version int64) (*User, error)
+	delete(ctx context.Context, id string, hard bool) error
 }
 
 type userService struct {
 	coll *mongo.Collection
 }
 
-func (s *userService) get(ctx context.Context, id string) (*User, error) {
+func (s *userService) get(ctx context.Context, id string, includeDeleted bool) (*User, error) {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid user ID: %w", err)
+		return nil, httperr.ErrInvalidID
+	}
+
+	filter := bson.D{{Key: "_id", Value: objectId}}
+	if !includeDeleted {
+		filter = append(filter, bson.E{Key: "deletedAt", Value: nil})
 	}
 
 	var user User
-	err = s.coll.FindOne(ctx, bson.D{{"_id", objectId}}).Decode(&user)
+	err = s.coll.FindOne(ctx, filter).Decode(&user)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, err
+			return nil, httperr.ErrNotFound
 		}
 		return nil, fmt.Errorf("failed to find User: %w", err)
 	}
@@ -134,9 +306,61 @@ func (s *userService) get(ctx context.Context, id string) (*User, error) {
 	return &user, nil
 }
 
+// exists reports whether a (non soft-deleted) user document with id exists,
+// used to tell a version conflict apart from a missing document on update.
+func (s *userService) exists(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	count, err := s.coll.CountDocuments(ctx, bson.D{{Key: "_id", Value: id}, {Key: "deletedAt", Value: nil}})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *userService) list(ctx context.Context, filter, sort bson.D, page, pageSize int) ([]*User, int64, error) {
+	opts := options.Find().
+		SetSort(sort).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	var (
+		users             []*User
+		total             int64
+		findErr, countErr error
+	)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		cursor, err := s.coll.Find(ctx, filter, opts)
+		if err != nil {
+			findErr = err
+			return
+		}
+		findErr = cursor.All(ctx, &users)
+	}()
+	go func() {
+		defer wg.Done()
+		total, countErr = s.coll.CountDocuments(ctx, filter)
+	}()
+	wg.Wait()
+
+	if findErr != nil {
+		return nil, 0, fmt.Errorf("failed to list users: %w", findErr)
+	}
+	if countErr != nil {
+		return nil, 0, fmt.Errorf("failed to count users: %w", countErr)
+	}
+
+	return users, total, nil
+}
+
 func (s *userService) create(ctx context.Context, user *User) error {
 	now := time.Now().UTC().Format(time.RFC3339)
 	user.CreatedAt = &now
+	user.UpdatedAt = &now
+	version := int64(1)
+	user.Version = &version
 
 	res, err := s.coll.InsertOne(ctx, user)
 	if err != nil {
@@ -150,32 +374,78 @@ func (s *userService) create(ctx context.Context, user *User) error {
 	return nil
 }
 
-func (s *userService) update(ctx context.Context, id string, user *User) (*User, error) {
+// update applies user's fields to the non-deleted document matching id and
+// version, then bumps version by one. version must match the document's
+// current version; a mismatch against an existing document yields
+// httperr.ErrVersionConflict rather than silently overwriting it.
+func (s *userService) update(ctx context.Context, id string, user *User, version int64) (*User, error) {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse user ID: %w", err)
+		return nil, httperr.ErrInvalidID
 	}
 
-	res, err := s.coll.UpdateByID(ctx, objectId, bson.D{{Key: "$set", Value: user}})
+	now := time.Now().UTC().Format(time.RFC3339)
+	user.UpdatedAt = &now
+
+	filter := bson.D{
+		{Key: "_id", Value: objectId},
+		{Key: "deletedAt", Value: nil},
+		{Key: "version", Value: version},
+	}
+	update := bson.D{
+		{Key: "$set", Value: user},
+		{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+	}
+	res, err := s.coll.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return nil, fmt.Errorf("failed to modify user with id %s: %w", id, err)
 	}
 	if res.MatchedCount < 1 {
-		return nil, nil
+		exists, err := s.exists(ctx, objectId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check user with id %s: %w", id, err)
+		}
+		if exists {
+			return nil, httperr.ErrVersionConflict
+		}
+		return nil, httperr.ErrNotFound
 	}
 
+	newVersion := version + 1
+	user.Version = &newVersion
 	return user, nil
 }
 
-func (s *userService) delete(ctx context.Context, id string) error {
+// delete soft-deletes the user by default, stamping deletedAt, or removes
+// the document permanently when hard is true.
+func (s *userService) delete(ctx context.Context, id string, hard bool) error {
 	objectId, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return fmt.Errorf("invalid user ID")
+		return httperr.ErrInvalidID
+	}
+
+	if hard {
+		res, err := s.coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: objectId}})
+		if err != nil {
+			return fmt.Errorf("failed to delete user with id %s: %w", id, err)
+		}
+		if res.DeletedCount < 1 {
+			return httperr.ErrNotFound
+		}
+		return nil
 	}
 
-	if _, err = s.coll.DeleteOne(ctx, bson.D{{"_id", objectId}}); err != nil {
+	now := time.Now().UTC().Format(time.RFC3339)
+	res, err := s.coll.UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: objectId}, {Key: "deletedAt", Value: nil}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "deletedAt", Value: now}}}},
+	)
+	if err != nil {
 		return fmt.Errorf("failed to delete user with id %s: %w", id, err)
 	}
+	if res.MatchedCount < 1 {
+		return httperr.ErrNotFound
+	}
 
 	return nil
 }
@@ -7,13 +7,18 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+
+	"ryde/httperr"
 )
 
 type MockDb struct {
@@ -35,26 +40,35 @@ func stringPtr(s string) *string {
 	return &s
 }
 
+func noAuth(c *gin.Context) {
+	c.Next()
+}
+
 type MockUserService struct {
 	mockGet    func() (*User, error)
+	mockList   func() ([]*User, int64, error)
 	mockCreate func() error
 	mockUpdate func() (*User, error)
 	mockDelete func() error
 }
 
-func (m MockUserService) get(context.Context, string) (*User, error) {
+func (m MockUserService) get(context.Context, string, bool) (*User, error) {
 	return m.mockGet()
 }
 
+func (m MockUserService) list(context.Context, bson.D, bson.D, int, int) ([]*User, int64, error) {
+	return m.mockList()
+}
+
 func (m MockUserService) create(context.Context, *User) error {
 	return m.mockCreate()
 }
 
-func (m MockUserService) update(context.Context, string, *User) (*User, error) {
+func (m MockUserService) update(context.Context, string, *User, int64) (*User, error) {
 	return m.mockUpdate()
 }
 
-func (m MockUserService) delete(context.Context, string) error {
+func (m MockUserService) delete(context.Context, string, bool) error {
 	return m.mockDelete()
 }
 
@@ -63,6 +77,9 @@ func NewMockUserService() MockUserService {
 		mockGet: func() (*User, error) {
 			return nil, nil
 		},
+		mockList: func() ([]*User, int64, error) {
+			return nil, 0, nil
+		},
 		mockCreate: func() error {
 			return nil
 		},
@@ -94,7 +111,7 @@ func TestUserControllerGetUser(t *testing.T) {
 	svc.mockGet = func() (*User, error) {
 		return user, nil
 	}
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/apis/users/"+id, nil)
@@ -111,6 +128,102 @@ func TestUserControllerGetUser(t *testing.T) {
 	assert.Equal(t, "now", *got.CreatedAt)
 }
 
+func TestUserControllerListUsers(t *testing.T) {
+	setup()
+	r := gin.Default()
+	svc := NewMockUserService()
+	svc.mockList = func() ([]*User, int64, error) {
+		return []*User{{Name: stringPtr("John Doe")}}, 1, nil
+	}
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/apis/users?page=2&pageSize=10&sort=-createdAt&name__contains=John", nil)
+	r.ServeHTTP(w, req)
+
+	var got struct {
+		Data     []*User `json:"data"`
+		Page     int     `json:"page"`
+		PageSize int     `json:"pageSize"`
+		Total    int64   `json:"total"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &got)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 2, got.Page)
+	assert.Equal(t, 10, got.PageSize)
+	assert.Equal(t, int64(1), got.Total)
+	assert.Len(t, got.Data, 1)
+	assert.Equal(t, "John Doe", *got.Data[0].Name)
+}
+
+func TestUserControllerListUsersIncludeDeleted(t *testing.T) {
+	setup()
+	r := gin.Default()
+	svc := NewMockUserService()
+	svc.mockList = func() ([]*User, int64, error) {
+		return []*User{{Name: stringPtr("Jane Doe")}}, 1, nil
+	}
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/apis/users?includeDeleted=true", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestParseUserFilterEscapesRegexMetacharacters(t *testing.T) {
+	filter, err := parseUserFilter(url.Values{"name__contains": {"a.*(b|c)+"}})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "name", Value: bson.D{
+		{Key: "$regex", Value: regexp.QuoteMeta("a.*(b|c)+")},
+		{Key: "$options", Value: "i"},
+	}}}, filter)
+}
+
+func TestUserControllerListUsersUnknownFilterField(t *testing.T) {
+	setup()
+	r := gin.Default()
+	svc := NewMockUserService()
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/apis/users?password__eq=hunter2", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserControllerListUsersInvalidPage(t *testing.T) {
+	setup()
+	r := gin.Default()
+	svc := NewMockUserService()
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/apis/users?page=0", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserControllerListUsersErr(t *testing.T) {
+	setup()
+	r := gin.Default()
+	svc := NewMockUserService()
+	svc.mockList = func() ([]*User, int64, error) {
+		return nil, 0, fmt.Errorf("oops")
+	}
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/apis/users", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
 func TestUserControllerGetUserNotFound(t *testing.T) {
 	setup()
 	r := gin.Default()
@@ -118,20 +231,20 @@ func TestUserControllerGetUserNotFound(t *testing.T) {
 	svc.mockGet = func() (*User, error) {
 		return nil, mongo.ErrNoDocuments
 	}
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/apis/users/1", nil)
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
 func TestUserControllerCreateUser(t *testing.T) {
 	setup()
 	r := gin.Default()
 	svc := NewMockUserService()
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	payload := struct {
 		Name        string `json:"name" binding:"required"`
@@ -166,7 +279,7 @@ func TestUserControllerCreateUserErr(t *testing.T) {
 	svc.mockCreate = func() error {
 		return fmt.Errorf("oops")
 	}
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	payload := struct {
 		Name        string `json:"name" binding:"required"`
@@ -206,18 +319,20 @@ func TestUserControllerUpdateUser(t *testing.T) {
 	svc.mockUpdate = func() (*User, error) {
 		return updatedUser, nil
 	}
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	payload := struct {
 		Name        string `json:"name" bson:"name,omitempty"`
 		Dob         string `json:"dob" bson:"dob,omitempty"`
 		Address     string `json:"address" bson:"address,omitempty"`
 		Description string `json:"description" bson:"description,omitempty"`
+		Version     int64  `json:"version"`
 	}{
 		Name:        "John Doe",
 		Dob:         "1/1/2022",
 		Address:     "1 Singapore Road",
 		Description: "test update user",
+		Version:     1,
 	}
 
 	body, _ := json.Marshal(payload)
@@ -239,18 +354,23 @@ func TestUserControllerUpdateUserNotFound(t *testing.T) {
 	setup()
 	r := gin.Default()
 	svc := NewMockUserService()
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	svc.mockUpdate = func() (*User, error) {
+		return nil, httperr.ErrNotFound
+	}
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	payload := struct {
 		Name        string `json:"name" bson:"name,omitempty"`
 		Dob         string `json:"dob" bson:"dob,omitempty"`
 		Address     string `json:"address" bson:"address,omitempty"`
 		Description string `json:"description" bson:"description,omitempty"`
+		Version     int64  `json:"version"`
 	}{
 		Name:        "John Doe",
 		Dob:         "1/1/2022",
 		Address:     "1 Singapore Road",
 		Description: "test update user",
+		Version:     1,
 	}
 
 	body, _ := json.Marshal(payload)
@@ -258,7 +378,38 @@ func TestUserControllerUpdateUserNotFound(t *testing.T) {
 	req, _ := http.NewRequest(http.MethodPut, "/apis/users/1", bytes.NewBuffer(body))
 	r.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUserControllerUpdateUserVersionConflict(t *testing.T) {
+	setup()
+	r := gin.Default()
+	svc := NewMockUserService()
+	svc.mockUpdate = func() (*User, error) {
+		return nil, httperr.ErrVersionConflict
+	}
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
+
+	payload := struct {
+		Name        string `json:"name" bson:"name,omitempty"`
+		Dob         string `json:"dob" bson:"dob,omitempty"`
+		Address     string `json:"address" bson:"address,omitempty"`
+		Description string `json:"description" bson:"description,omitempty"`
+		Version     int64  `json:"version"`
+	}{
+		Name:        "John Doe",
+		Dob:         "1/1/2022",
+		Address:     "1 Singapore Road",
+		Description: "test update user",
+		Version:     1,
+	}
+
+	body, _ := json.Marshal(payload)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/apis/users/1", bytes.NewBuffer(body))
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
 }
 
 func TestUserControllerUpdateUserErr(t *testing.T) {
@@ -268,18 +419,20 @@ func TestUserControllerUpdateUserErr(t *testing.T) {
 	svc.mockUpdate = func() (*User, error) {
 		return nil, fmt.Errorf("oops")
 	}
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	payload := struct {
 		Name        string `json:"name" bson:"name,omitempty"`
 		Dob         string `json:"dob" bson:"dob,omitempty"`
 		Address     string `json:"address" bson:"address,omitempty"`
 		Description string `json:"description" bson:"description,omitempty"`
+		Version     int64  `json:"version"`
 	}{
 		Name:        "John Doe",
 		Dob:         "1/1/2022",
 		Address:     "1 Singapore Road",
 		Description: "test update user",
+		Version:     1,
 	}
 
 	body, _ := json.Marshal(payload)
@@ -294,7 +447,7 @@ func TestUserControllerDeleteUser(t *testing.T) {
 	setup()
 	r := gin.Default()
 	svc := NewMockUserService()
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/apis/users/1", nil)
@@ -310,7 +463,7 @@ func TestUserControllerDeleteUserErr(t *testing.T) {
 	svc.mockDelete = func() error {
 		return fmt.Errorf("oops")
 	}
-	userRoutes(r.Group("/apis"), &UserController{svc})
+	userRoutes(r.Group("/apis"), &UserController{svc}, noAuth)
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/apis/users/1", nil)